@@ -0,0 +1,156 @@
+package qoi
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+)
+
+// ANIMATION_MAGIC_BYTES identifies the qoia animated-QOI container.
+const ANIMATION_MAGIC_BYTES string = "qoia"
+
+// AnimationFrame is one frame of an animated QOI container: an image plus
+// the timing/positioning metadata needed to composite it onto the overall
+// animation canvas, analogous to a sub-image in container formats like
+// APNG.
+type AnimationFrame struct {
+	// DelayMs is how long this frame should be displayed for, in
+	// milliseconds.
+	DelayMs uint32
+	// OffsetX/OffsetY place this frame's top-left corner within the
+	// overall animation canvas.
+	OffsetX int32
+	OffsetY int32
+	// Image is the frame's pixel data, encoded as a regular QOI payload.
+	Image image.Image
+}
+
+// Animation is a decoded qoia container: an ordered sequence of frames.
+type Animation struct {
+	Frames []AnimationFrame
+}
+
+// FirstFrame returns a's first frame image, or nil if the animation has no
+// frames. It's what lets a qoia container stand in for a plain image.Image.
+func (a *Animation) FirstFrame() image.Image {
+	if len(a.Frames) == 0 {
+		return nil
+	}
+	return a.Frames[0].Image
+}
+
+// EncodeAnimation writes frames to w as a qoia container: a 4-byte magic, a
+// frame count, and then each frame's delay/offset/size metadata followed by
+// its QOI-encoded payload, so it reuses the existing per-frame encoder
+// rather than inventing a new pixel format.
+func EncodeAnimation(w io.Writer, frames []AnimationFrame) error {
+	if _, err := io.WriteString(w, ANIMATION_MAGIC_BYTES); err != nil {
+		return err
+	}
+
+	count := make([]byte, 4)
+	binary.BigEndian.PutUint32(count, uint32(len(frames)))
+	if _, err := w.Write(count); err != nil {
+		return err
+	}
+
+	for i, frame := range frames {
+		b := frame.Image.Bounds()
+		meta := make([]byte, 20)
+		binary.BigEndian.PutUint32(meta[0:4], frame.DelayMs)
+		binary.BigEndian.PutUint32(meta[4:8], uint32(frame.OffsetX))
+		binary.BigEndian.PutUint32(meta[8:12], uint32(frame.OffsetY))
+		binary.BigEndian.PutUint32(meta[12:16], uint32(b.Dx()))
+		binary.BigEndian.PutUint32(meta[16:20], uint32(b.Dy()))
+		if _, err := w.Write(meta); err != nil {
+			return err
+		}
+
+		if err := ImageEncode(w, frame.Image); err != nil {
+			return fmt.Errorf("failed to encode frame %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// DecodeAnimation reads a qoia container written by EncodeAnimation. It
+// wraps r in a single shared *bufio.Reader up front and reuses it for every
+// frame: ImageDecode reuses, rather than re-wraps, a *bufio.Reader it's
+// handed (see asBufioReader), so the frames stay aligned on one stream
+// instead of each frame's Decoder read-ahead getting discarded along with
+// its throwaway bufio.Reader.
+func DecodeAnimation(r io.Reader) (*Animation, error) {
+	br := asBufioReader(r)
+
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(br, magic); err != nil {
+		return nil, fmt.Errorf("failed to read qoia magic: %w", err)
+	}
+	if string(magic) != ANIMATION_MAGIC_BYTES {
+		return nil, fmt.Errorf("file does not start with qoia magic bytes, found %s", magic)
+	}
+
+	countBytes := make([]byte, 4)
+	if _, err := io.ReadFull(br, countBytes); err != nil {
+		return nil, fmt.Errorf("failed to read qoia frame count: %w", err)
+	}
+	frameCount := binary.BigEndian.Uint32(countBytes)
+
+	anim := &Animation{Frames: make([]AnimationFrame, 0, frameCount)}
+	for i := uint32(0); i < frameCount; i++ {
+		meta := make([]byte, 20)
+		if _, err := io.ReadFull(br, meta); err != nil {
+			return nil, fmt.Errorf("failed to read metadata for frame %d: %w", i, err)
+		}
+
+		frame := AnimationFrame{
+			DelayMs: binary.BigEndian.Uint32(meta[0:4]),
+			OffsetX: int32(binary.BigEndian.Uint32(meta[4:8])),
+			OffsetY: int32(binary.BigEndian.Uint32(meta[8:12])),
+		}
+
+		img, err := ImageDecode(br)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode frame %d: %w", i, err)
+		}
+		frame.Image = img
+
+		anim.Frames = append(anim.Frames, frame)
+	}
+
+	return anim, nil
+}
+
+// DecodeAnimationFirstFrame decodes a qoia container and returns only its
+// first frame, so image.RegisterFormat/image.Decode can treat qoia files
+// like any other single-frame image format.
+func DecodeAnimationFirstFrame(r io.Reader) (image.Image, error) {
+	anim, err := DecodeAnimation(r)
+	if err != nil {
+		return nil, err
+	}
+	return anim.FirstFrame(), nil
+}
+
+// AnimationConfig decodes a qoia container and reports its first frame's
+// dimensions and color model, for use as an image.RegisterFormat
+// DecodeConfig func. Registration of qoia lives in the qoi/register
+// subpackage, not here, so importing qoi registers only the plain qoi
+// format, not qoia (see register/register.go).
+func AnimationConfig(r io.Reader) (image.Config, error) {
+	anim, err := DecodeAnimation(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+
+	first := anim.FirstFrame()
+	if first == nil {
+		return image.Config{}, fmt.Errorf("qoia container has no frames")
+	}
+
+	b := first.Bounds()
+	return image.Config{Width: b.Dx(), Height: b.Dy(), ColorModel: color.NRGBAModel}, nil
+}
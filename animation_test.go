@@ -0,0 +1,68 @@
+package qoi_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/kriticalflare/qoi"
+)
+
+func solidNRGBA(w, h int, c color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// TestAnimationRoundTrip encodes and decodes a qoia container with several
+// frames, each a different size and color, to catch consecutive frames
+// sharing one underlying reader getting desynced (each frame must end exactly where
+// its own end marker says it does, not bleed into the next).
+func TestAnimationRoundTrip(t *testing.T) {
+	frames := []qoi.AnimationFrame{
+		{DelayMs: 100, Image: solidNRGBA(2, 2, color.NRGBA{R: 255, A: 255})},
+		{DelayMs: 150, OffsetX: 1, OffsetY: 1, Image: solidNRGBA(3, 1, color.NRGBA{G: 255, A: 255})},
+		{DelayMs: 200, Image: solidNRGBA(1, 4, color.NRGBA{B: 255, A: 128})},
+	}
+
+	var buf bytes.Buffer
+	if err := qoi.EncodeAnimation(&buf, frames); err != nil {
+		t.Fatalf("EncodeAnimation failed: %v", err)
+	}
+
+	anim, err := qoi.DecodeAnimation(&buf)
+	if err != nil {
+		t.Fatalf("DecodeAnimation failed: %v", err)
+	}
+
+	if len(anim.Frames) != len(frames) {
+		t.Fatalf("got %d frames, want %d", len(anim.Frames), len(frames))
+	}
+
+	for i, want := range frames {
+		got := anim.Frames[i]
+		if got.DelayMs != want.DelayMs || got.OffsetX != want.OffsetX || got.OffsetY != want.OffsetY {
+			t.Fatalf("frame %d metadata: got %+v, want delay=%d offsetX=%d offsetY=%d", i, got, want.DelayMs, want.OffsetX, want.OffsetY)
+		}
+
+		wantBounds := want.Image.Bounds()
+		if !got.Image.Bounds().Eq(wantBounds) {
+			t.Fatalf("frame %d bounds: got %v, want %v", i, got.Image.Bounds(), wantBounds)
+		}
+
+		for y := wantBounds.Min.Y; y < wantBounds.Max.Y; y++ {
+			for x := wantBounds.Min.X; x < wantBounds.Max.X; x++ {
+				wr, wg, wb, wa := want.Image.At(x, y).RGBA()
+				gr, gg, gb, ga := got.Image.At(x, y).RGBA()
+				if wr != gr || wg != gg || wb != gb || wa != ga {
+					t.Fatalf("frame %d pixel (%d,%d): got %v, want %v", i, x, y, got.Image.At(x, y), want.Image.At(x, y))
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,124 @@
+// Command qoi transcodes images between QOI and the image formats the Go
+// standard library and golang.org/x/image know how to decode: PNG, BMP, and
+// TIFF today. It autodetects the input format via image.Decode and picks
+// the output format from the output file's extension.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/tiff"
+
+	"github.com/kriticalflare/qoi"
+	_ "github.com/kriticalflare/qoi/register"
+)
+
+func init() {
+	image.RegisterFormat("bmp", "BM", bmp.Decode, bmp.DecodeConfig)
+	image.RegisterFormat("tiff", "II*\x00", tiff.Decode, tiff.DecodeConfig)
+	image.RegisterFormat("tiff", "MM\x00*", tiff.Decode, tiff.DecodeConfig)
+}
+
+func main() {
+	channels := flag.Int("channels", 0, "force the QOI channel count (3 or 4) when encoding to QOI; 0 infers from the source image")
+	colorspace := flag.Int("colorspace", 0, "QOI colorspace byte to write when encoding to QOI (0 sRGB, 1 linear)")
+	out := flag.String("o", "", "output path; only valid when a single input file is given")
+	flag.Parse()
+
+	patterns := flag.Args()
+	if len(patterns) == 0 {
+		log.Fatal("usage: qoi [-channels N] [-colorspace N] [-o out] <file-or-glob>...")
+	}
+
+	var inputs []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			log.Fatalf("invalid pattern %q: %v", pattern, err)
+		}
+		if len(matches) == 0 {
+			matches = []string{pattern}
+		}
+		inputs = append(inputs, matches...)
+	}
+
+	if *out != "" && len(inputs) > 1 {
+		log.Fatal("-o can only be used when converting a single file")
+	}
+
+	for _, in := range inputs {
+		outPath := *out
+		if outPath == "" {
+			outPath = defaultOutputPath(in)
+		}
+		if err := convert(in, outPath, uint8(*channels), uint8(*colorspace)); err != nil {
+			log.Fatalf("failed to convert %s: %v", in, err)
+		}
+		fmt.Printf("%s -> %s\n", in, outPath)
+	}
+}
+
+// defaultOutputPath swaps a file's extension between .qoi and .png, so
+// batch/glob mode works without an explicit -o.
+func defaultOutputPath(in string) string {
+	base := strings.TrimSuffix(in, filepath.Ext(in))
+	if strings.EqualFold(filepath.Ext(in), ".qoi") {
+		return base + ".png"
+	}
+	return base + ".qoi"
+}
+
+func convert(inPath, outPath string, channels, colorspace uint8) error {
+	in, err := os.Open(inPath)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	img, format, err := image.Decode(in)
+	if err != nil {
+		return fmt.Errorf("decode: %w", err)
+	}
+
+	out, err := os.Create(outPath)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if strings.EqualFold(filepath.Ext(outPath), ".qoi") {
+		var opts *qoi.EncoderOptions
+		if channels != 0 || colorspace != 0 {
+			opts = &qoi.EncoderOptions{Channels: channels, Colorspace: colorspace}
+		}
+		return qoi.EncodeWithOptions(out, img, opts)
+	}
+
+	if format == "qoi" || format == "qoia" {
+		return encodeNonQOI(out, outPath, img)
+	}
+
+	return fmt.Errorf("refusing to convert %s to %s: both are non-QOI formats", format, outPath)
+}
+
+// encodeNonQOI writes img using the encoder implied by outPath's extension,
+// for the QOI -> PNG/BMP/TIFF direction.
+func encodeNonQOI(w io.Writer, outPath string, img image.Image) error {
+	switch strings.ToLower(filepath.Ext(outPath)) {
+	case ".bmp":
+		return bmp.Encode(w, img)
+	case ".tif", ".tiff":
+		return tiff.Encode(w, img, nil)
+	default:
+		return png.Encode(w, img)
+	}
+}
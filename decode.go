@@ -1,13 +1,13 @@
 package qoi
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/binary"
 	"fmt"
 	"image"
 	"image/color"
 	"io"
-	"log"
-	"os"
 )
 
 type header struct {
@@ -20,6 +20,14 @@ type header struct {
 
 const MAGIC_BYTES string = "qoif"
 
+// init registers the qoi format with the standard library's image package,
+// the same way image/png and the x/image codecs do, so image.Decode and
+// image.DecodeConfig transparently handle .qoi files for anyone who imports
+// this package - no separate opt-in import required.
+func init() {
+	image.RegisterFormat("qoi", MAGIC_BYTES, ImageDecode, DecodeConfig)
+}
+
 func readHeader(file []byte) (*header, error) {
 	if len(file) < 14 {
 		return nil, fmt.Errorf("QOI header is 14 bytes long, got %d bytes", len(file))
@@ -53,6 +61,10 @@ func (p pixel) Equals(other pixel) bool {
 	return (p.R == other.R) && (p.G == other.G) && (p.B == other.B) && (p.A == other.A)
 }
 
+func (p pixel) toNRGBA() color.NRGBA {
+	return color.NRGBA{R: p.R, G: p.G, B: p.B, A: p.A}
+}
+
 type chunkType int
 
 const (
@@ -63,8 +75,49 @@ const (
 	qoi_op_diff
 	qoi_op_luma
 	qoi_op_run
+	qoi_op_end_marker
 )
 
+func (c chunkType) String() string {
+	switch c {
+	case qoi_op_rgb:
+		return "QOI_OP_RGB"
+	case qoi_op_rgba:
+		return "QOI_OP_RGBA"
+	case qoi_op_index:
+		return "QOI_OP_INDEX"
+	case qoi_op_diff:
+		return "QOI_OP_DIFF"
+	case qoi_op_luma:
+		return "QOI_OP_LUMA"
+	case qoi_op_run:
+		return "QOI_OP_RUN"
+	case qoi_op_end_marker:
+		return "end marker"
+	default:
+		return "unknown chunk"
+	}
+}
+
+// DecodeError reports a QOI stream that couldn't be parsed: a truncated or
+// out-of-range read, or a malformed end marker. Offset is the byte position
+// in the stream where the failing read started, and Chunk is the kind of
+// chunk being parsed at the time, so callers can pinpoint where a corrupt
+// file first goes wrong instead of just seeing "unexpected EOF".
+type DecodeError struct {
+	Offset int64
+	Chunk  chunkType
+	Err    error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("qoi: decode error at offset %d (%s): %v", e.Offset, e.Chunk, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
 type State struct {
 	Raw           []pixel
 	historyBuffer [64]pixel
@@ -81,200 +134,332 @@ func NewState() State {
 	return state
 }
 
-func Decode(buffer []byte) (*State, error) {
+// DefaultMaxPixels bounds header.Width*header.Height for a Decoder that
+// wasn't given an explicit DecoderOptions.MaxPixels. It guards against a
+// corrupt or hostile header claiming a huge image forcing a multi-gigabyte
+// allocation before a single byte of pixel data has even been checked; 400
+// megapixels comfortably covers any real-world QOI image.
+const DefaultMaxPixels = 400_000_000
+
+// DecoderOptions configures NewDecoderWithOptions.
+type DecoderOptions struct {
+	// MaxPixels caps header.Width*header.Height. Zero means DefaultMaxPixels.
+	MaxPixels int
+}
 
-	header, err := readHeader(buffer)
+// Decoder pulls QOI-encoded pixels one at a time from an underlying
+// io.Reader, so a multi-hundred-MB file never needs to be fully buffered
+// just to read it. It wraps the reader in a bufio.Reader, the same small
+// growable-buffer approach x/image/tiff uses to avoid re-reading a raw
+// io.Reader byte by byte.
+type Decoder struct {
+	r *bufio.Reader
+	header
+
+	historyBuffer [64]pixel
+	previousPixel pixel
+
+	expectedPixelsCount int
+	pixelsRead          int
+	runRemaining        int
+	byteOffset          int64
+}
+
+// NewDecoder reads and validates the 14-byte QOI header from r and returns a
+// Decoder ready to emit pixels via Next. It's equivalent to
+// NewDecoderWithOptions(r, nil).
+func NewDecoder(r io.Reader) (*Decoder, error) {
+	return NewDecoderWithOptions(r, nil)
+}
+
+// asBufioReader wraps r in a bufio.Reader, unless r already is one. Reusing
+// an existing *bufio.Reader matters for callers like DecodeAnimation that
+// decode several QOI payloads back-to-back out of one stream: a fresh
+// bufio.Reader reads ahead into whatever comes after the current payload's
+// end marker, and any of that read-ahead is lost once this Decoder is
+// discarded, desyncing the next payload's read. Sharing the *bufio.Reader
+// across payloads keeps everything read-ahead and all in one place.
+func asBufioReader(r io.Reader) *bufio.Reader {
+	if br, ok := r.(*bufio.Reader); ok {
+		return br
+	}
+	return bufio.NewReader(r)
+}
+
+// NewDecoderWithOptions is like NewDecoder but lets the caller override the
+// MaxPixels guard, e.g. to accept deliberately huge images or to clamp
+// lower than DefaultMaxPixels when decoding untrusted input.
+func NewDecoderWithOptions(r io.Reader, opts *DecoderOptions) (*Decoder, error) {
+	br := asBufioReader(r)
+
+	buf := make([]byte, 14)
+	if _, err := io.ReadFull(br, buf); err != nil {
+		return nil, fmt.Errorf("failed to read QOI header: %w", err)
+	}
+
+	h, err := readHeader(buf)
 	if err != nil {
 		return nil, err
 	}
 
-	s := NewState()
-	s.header = *header
-	var expectedPixelsCount int = int(s.Width * s.Height)
-	s.Raw = make([]pixel, expectedPixelsCount)
-
-	idx := 14 // header length
-	pixelsRead := 0
-
-PixelLoop:
-	for idx < len(buffer) && pixelsRead < expectedPixelsCount {
-		tag := buffer[idx]
-		switch {
-		case tag == 255:
-			// fmt.Printf("idx %d has 'qoi_op_rgba' chunk\n", idx)
-			pixel := pixel{R: buffer[idx+1], G: buffer[idx+2], B: buffer[idx+3], A: buffer[idx+4]}
-			s.historyBuffer[pixel.Hash()] = pixel
-			s.Raw[pixelsRead] = pixel
-			s.previousPixel = pixel
-			idx += 5
-			pixelsRead += 1
-
-		case tag == 254:
-			// fmt.Printf("idx %d has 'qoi_op_rgb' chunk\n", idx)
-			pixel := pixel{R: buffer[idx+1], G: buffer[idx+2], B: buffer[idx+3], A: s.previousPixel.A}
-			s.historyBuffer[pixel.Hash()] = pixel
-			s.Raw[pixelsRead] = pixel
-			s.previousPixel = pixel
-			idx += 4
-			pixelsRead += 1
-
-		case expectedPixelsCount == pixelsRead:
-			// fmt.Printf("idx %d end marker -  tag %b \n", idx, buffer[idx:])
-			break PixelLoop
-
-		case (tag >> 6) == 0:
-			pix := s.historyBuffer[tag]
-			// fmt.Printf("idx %d has 'qoi_op_index' chunk -  tag %08b - historyBufferIdx %d Pixel %v \n", idx, tag, tag, pixel)
-			pix = pixel{R: pix.R, G: pix.G, B: pix.B, A: pix.A}
-			s.Raw[pixelsRead] = pix
-			s.previousPixel = pix
-			idx += 1
-			pixelsRead += 1
-
-		case (tag >> 6) == 1:
-			var bias byte = 2
-			// fmt.Printf("idx %d has 'qoi_op_diff' chunk -  tag %08b - %d ", idx, tag, tag>>6)
-			rMask := byte(0b00110000)
-			gMask := byte(0b00001100)
-			bMask := byte(0b00000011)
-
-			r := s.previousPixel.R + ((tag & rMask) >> 4) - bias
-			g := s.previousPixel.G + ((tag & gMask) >> 2) - bias
-			b := s.previousPixel.B + ((tag & bMask) >> 0) - bias
-			a := s.previousPixel.A
-
-			pixel := pixel{R: r, G: g, B: b, A: a}
-
-			// fmt.Printf("Pixel %v  Hash %v \n", pixel, pixel.Hash())
-
-			s.historyBuffer[pixel.Hash()] = pixel
-			s.Raw[pixelsRead] = pixel
-			s.previousPixel = pixel
-			idx += 1
-			pixelsRead += 1
-
-		case (tag >> 6) == 2:
-			// fmt.Printf("idx %d has 'qoi_op_luma' chunk -  tag %08b - %d ", idx, tag, tag>>6)
-
-			pixel := pixel{A: s.previousPixel.A}
-
-			dgBias := byte(32)
-			dgMask := byte(0b00111111)
-
-			drDgBias := byte(8)
-			drDgMask := byte(0b11110000)
-
-			dbDgBias := byte(8)
-			dbDgMask := byte(0b00001111)
-
-			rbByte := buffer[idx+1]
-
-			pixel.G = (tag & dgMask) - dgBias + s.previousPixel.G
-			pixel.R = ((rbByte & drDgMask) >> 4) - drDgBias + s.previousPixel.R + pixel.G - s.previousPixel.G
-			pixel.B = (rbByte & dbDgMask) - dbDgBias + s.previousPixel.B + pixel.G - s.previousPixel.G
-
-			s.historyBuffer[pixel.Hash()] = pixel
-			s.Raw[pixelsRead] = pixel
-			s.previousPixel = pixel
-
-			// fmt.Printf("%08b Pixel %v \n", buffer[idx:idx+2], pixel)
-
-			idx += 2
-			pixelsRead += 1
-		case (tag >> 6) == 3:
-			runLength := int((tag<<2)>>2) + 1
-			// fmt.Printf("idx %d has 'qoi_op_run' chunk -  tag %08b - RUN - %d \n", idx, tag, runLength)
-			if pixelsRead == 0 {
-				s.historyBuffer[s.previousPixel.Hash()] = s.previousPixel // https://github.com/phoboslab/qoi/issues/258
-			}
-			for rIdx := pixelsRead; rIdx < pixelsRead+runLength; rIdx++ {
-				s.Raw[rIdx] = pixel{R: s.previousPixel.R, G: s.previousPixel.G, B: s.previousPixel.B, A: s.previousPixel.A}
-				s.previousPixel = s.Raw[rIdx]
-			}
-			idx += 1
-			pixelsRead += runLength
-		}
+	maxPixels := DefaultMaxPixels
+	if opts != nil && opts.MaxPixels != 0 {
+		maxPixels = opts.MaxPixels
 	}
 
-	if pixelsRead != expectedPixelsCount {
-		return nil, fmt.Errorf("expected %d Pixels, found %d ", expectedPixelsCount, pixelsRead)
+	pixelCount := uint64(h.Width) * uint64(h.Height)
+	if pixelCount > uint64(maxPixels) {
+		return nil, fmt.Errorf("qoi: %dx%d image (%d pixels) exceeds MaxPixels limit of %d", h.Width, h.Height, pixelCount, maxPixels)
 	}
 
-	return &s, nil
+	d := &Decoder{r: br, header: *h, byteOffset: 14}
+	d.previousPixel = pixel{R: 0, G: 0, B: 0, A: 255}
+	d.expectedPixelsCount = int(pixelCount)
+	return d, nil
 }
 
-func ImageDecode(r io.Reader) (image.Image, error) {
-	data, err := io.ReadAll(r)
+// readByte reads one byte, attributing any failure to the chunk currently
+// being parsed and translating a bare io.EOF into io.ErrUnexpectedEOF: at
+// this point we're mid-chunk, so running out of input is always truncation,
+// never a clean end of stream.
+func (d *Decoder) readByte(ct chunkType) (byte, error) {
+	b, err := d.r.ReadByte()
 	if err != nil {
-		return nil, err
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, &DecodeError{Offset: d.byteOffset, Chunk: ct, Err: err}
 	}
-	state, err := Decode(data)
-	if err != nil {
-		return nil, err
+	d.byteOffset++
+	return b, nil
+}
+
+// readFull fills buf, attributing any failure to ct the same way readByte
+// does.
+func (d *Decoder) readFull(ct chunkType, buf []byte) error {
+	if _, err := io.ReadFull(d.r, buf); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return &DecodeError{Offset: d.byteOffset, Chunk: ct, Err: err}
 	}
+	d.byteOffset += int64(len(buf))
+	return nil
+}
 
-	img := image.NewNRGBA(image.Rect(0, 0, int(state.Width), int(state.Height)))
-	for idx, pixel := range state.Raw {
-		img.Set(idx%int(state.Width), idx/int(state.Width), color.NRGBA{
-			R: pixel.R,
-			G: pixel.G,
-			B: pixel.B,
-			A: pixel.A,
-		})
+// readEndMarker consumes and validates the 8-byte QOI end marker that
+// follows the last pixel. It does not check for trailing bytes after the
+// marker, since some callers (the qoia animation container) pack more data
+// there; Decode, which owns the whole buffer, checks that separately.
+func (d *Decoder) readEndMarker() error {
+	marker := make([]byte, len(END_MARKER))
+	offset := d.byteOffset
+	if err := d.readFull(qoi_op_end_marker, marker); err != nil {
+		return err
 	}
-	return img, nil
+	if !bytes.Equal(marker, END_MARKER) {
+		return &DecodeError{Offset: offset, Chunk: qoi_op_end_marker, Err: fmt.Errorf("invalid end marker: got %v, want %v", marker, END_MARKER)}
+	}
+	return nil
 }
 
-func DecodeConfig(r io.Reader) (image.Config, error) {
-	buffer := make([]byte, 14)
-	n, err := r.Read(buffer)
-	if err != nil || n != 14 {
-		return image.Config{}, err
+// Len reports the total number of pixels the decoder will produce.
+func (d *Decoder) Len() int {
+	return d.expectedPixelsCount
+}
+
+// Next decodes and returns the next pixel in the image. It returns io.EOF
+// once every pixel described by the header has been produced.
+func (d *Decoder) Next() (color.NRGBA, error) {
+	if d.pixelsRead >= d.expectedPixelsCount {
+		return color.NRGBA{}, io.EOF
 	}
 
-	header, err := readHeader(buffer)
+	if d.runRemaining > 0 {
+		d.runRemaining--
+		d.pixelsRead++
+		return d.previousPixel.toNRGBA(), nil
+	}
+
+	tag, err := d.readByte(UNKNOWN)
 	if err != nil {
-		return image.Config{}, err
+		return color.NRGBA{}, err
 	}
-	
-	return image.Config{
-		Height: int(header.Height),
-		Width:  int(header.Width),
-		ColorModel: color.RGBAModel ,
-	}, nil
+
+	var p pixel
+	switch {
+	case tag == 255:
+		var rest [4]byte
+		if err := d.readFull(qoi_op_rgba, rest[:]); err != nil {
+			return color.NRGBA{}, err
+		}
+		p = pixel{R: rest[0], G: rest[1], B: rest[2], A: rest[3]}
+		d.historyBuffer[p.Hash()] = p
+
+	case tag == 254:
+		var rest [3]byte
+		if err := d.readFull(qoi_op_rgb, rest[:]); err != nil {
+			return color.NRGBA{}, err
+		}
+		p = pixel{R: rest[0], G: rest[1], B: rest[2], A: d.previousPixel.A}
+		d.historyBuffer[p.Hash()] = p
+
+	case (tag >> 6) == 0:
+		p = d.historyBuffer[tag]
+
+	case (tag >> 6) == 1:
+		var bias byte = 2
+		rMask := byte(0b00110000)
+		gMask := byte(0b00001100)
+		bMask := byte(0b00000011)
+
+		p = pixel{
+			R: d.previousPixel.R + ((tag & rMask) >> 4) - bias,
+			G: d.previousPixel.G + ((tag & gMask) >> 2) - bias,
+			B: d.previousPixel.B + ((tag & bMask) >> 0) - bias,
+			A: d.previousPixel.A,
+		}
+		d.historyBuffer[p.Hash()] = p
+
+	case (tag >> 6) == 2:
+		rbByte, err := d.readByte(qoi_op_luma)
+		if err != nil {
+			return color.NRGBA{}, err
+		}
+
+		dgBias := byte(32)
+		dgMask := byte(0b00111111)
+
+		drDgBias := byte(8)
+		drDgMask := byte(0b11110000)
+
+		dbDgBias := byte(8)
+		dbDgMask := byte(0b00001111)
+
+		p.A = d.previousPixel.A
+		p.G = (tag & dgMask) - dgBias + d.previousPixel.G
+		p.R = ((rbByte & drDgMask) >> 4) - drDgBias + d.previousPixel.R + p.G - d.previousPixel.G
+		p.B = (rbByte & dbDgMask) - dbDgBias + d.previousPixel.B + p.G - d.previousPixel.G
+		d.historyBuffer[p.Hash()] = p
+
+	case (tag >> 6) == 3:
+		runLength := int((tag<<2)>>2) + 1
+		if d.pixelsRead == 0 {
+			d.historyBuffer[d.previousPixel.Hash()] = d.previousPixel // https://github.com/phoboslab/qoi/issues/258
+		}
+		p = d.previousPixel
+		d.runRemaining = runLength - 1
+	}
+
+	d.previousPixel = p
+	d.pixelsRead++
+	return p.toNRGBA(), nil
+}
+
+// ReadRow decodes one scanline's worth of pixels (d.Width of them) into
+// row, which must be at least that long. It lets a caller stream a QOI file
+// straight into another row-at-a-time encoder (e.g. png.Encoder) without
+// ever holding the full decoded raster in memory.
+func (d *Decoder) ReadRow(row []color.NRGBA) error {
+	width := int(d.Width)
+	if len(row) < width {
+		return fmt.Errorf("row buffer too small: need %d pixels, got %d", width, len(row))
+	}
+
+	for x := 0; x < width; x++ {
+		c, err := d.Next()
+		if err != nil {
+			return err
+		}
+		row[x] = c
+	}
+
+	return nil
 }
 
-func testDecode() *State {
-	// file, err := os.ReadFile("./testimages/dice.qoi")
-	// file, err := os.ReadFile("./testimages/edgecase.qoi")
-	file, err := os.ReadFile("./testimages/testcard_rgba.qoi")
-	// file, err := os.ReadFile("./testimages/kodim10.qoi")
-	// file, err := os.ReadFile("./testimages/kodim23.qoi")
-	// file, err := os.ReadFile("./testimages/wikipedia_008.qoi")
+// Decode decodes a complete in-memory QOI file. It is a thin wrapper around
+// Decoder for callers that already have the whole file buffered. It's
+// equivalent to DecodeWithOptions(buffer, nil).
+func Decode(buffer []byte) (*State, error) {
+	return DecodeWithOptions(buffer, nil)
+}
 
+// DecodeWithOptions is like Decode but lets the caller override the
+// MaxPixels guard. Like ImageDecode, it requires the 8-byte end marker to
+// be exactly where the format puts it; unlike the streaming
+// Decoder/ImageDecode, it also rejects any trailing bytes after it, since it
+// owns the whole buffer and knows precisely where the file should end.
+func DecodeWithOptions(buffer []byte, opts *DecoderOptions) (*State, error) {
+	d, err := NewDecoderWithOptions(bytes.NewReader(buffer), opts)
 	if err != nil {
-		log.Fatalf("failed to read file %v", err)
+		return nil, err
+	}
+
+	s := NewState()
+	s.header = d.header
+	s.Raw = make([]pixel, d.expectedPixelsCount)
+
+	for i := 0; i < d.expectedPixelsCount; i++ {
+		c, err := d.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode pixel %d: %w", i, err)
+		}
+		s.Raw[i] = pixel{R: c.R, G: c.G, B: c.B, A: c.A}
+	}
+
+	if err := d.readEndMarker(); err != nil {
+		return nil, err
+	}
+	if _, err := d.r.ReadByte(); err != io.EOF {
+		if err == nil {
+			return nil, &DecodeError{Offset: d.byteOffset, Chunk: qoi_op_end_marker, Err: fmt.Errorf("trailing data after end marker")}
+		}
+		return nil, err
 	}
 
-	qoiState, err := Decode(file)
+	return &s, nil
+}
+
+// ImageDecode decodes r as a QOI image. Like Decode, it requires a valid
+// 8-byte end marker after the last pixel, but (unlike Decode) doesn't
+// object to further bytes after it, since callers such as the qoia
+// animation container pack more frames there.
+func ImageDecode(r io.Reader) (image.Image, error) {
+	d, err := NewDecoder(r)
 	if err != nil {
-		log.Fatalf("Failed to decode QOI from buffer: %v", err)
+		return nil, err
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, int(d.Width), int(d.Height)))
+	for i := 0; i < d.expectedPixelsCount; i++ {
+		c, err := d.Next()
+		if err != nil {
+			return nil, err
+		}
+		img.Set(i%int(d.Width), i/int(d.Width), c)
 	}
-	fmt.Printf("%v\n", qoiState.header)
 
-	var outputBuffer []byte = make([]byte, len(qoiState.Raw)*4)
+	if err := d.readEndMarker(); err != nil {
+		return nil, err
+	}
+
+	return img, nil
+}
 
-	for idx, buf := range qoiState.Raw {
-		offset := idx * 4
-		outputBuffer[offset] = buf.R
-		outputBuffer[offset+1] = buf.G
-		outputBuffer[offset+2] = buf.B
-		outputBuffer[offset+3] = buf.A
+func DecodeConfig(r io.Reader) (image.Config, error) {
+	buffer := make([]byte, 14)
+	if _, err := io.ReadFull(r, buffer); err != nil {
+		return image.Config{}, fmt.Errorf("failed to read QOI header: %w", err)
 	}
 
-	err = os.WriteFile("./output/output.bin", outputBuffer, 0644)
+	header, err := readHeader(buffer)
 	if err != nil {
-		log.Fatalf("failed to write output file: %v", err)
+		return image.Config{}, err
 	}
-	return qoiState
+
+	return image.Config{
+		Height:     int(header.Height),
+		Width:      int(header.Width),
+		ColorModel: color.NRGBAModel,
+	}, nil
 }
@@ -9,11 +9,10 @@ import (
 	"strings"
 	"testing"
 
-	"github.com/kriticalflare/qoi"
+	_ "github.com/kriticalflare/qoi/register"
 )
 
 func TestImageDecoding(t *testing.T) {
-	image.RegisterFormat("qoi", qoi.MAGIC_BYTES, qoi.ImageDecode, qoi.DecodeConfig)
 	pngFiles, err := filepath.Glob("./testimages/*.png")
 	if err != nil {
 		t.Fatalf("failed to read png files: %v\n", err)
@@ -1,176 +1,270 @@
 package qoi
 
 import (
+	"bytes"
 	"encoding/binary"
 	"fmt"
 	"image"
+	"image/color"
 	"image/draw"
 	"io"
-	"slices"
+
+	"github.com/kriticalflare/qoi/qoiasm"
 )
 
-func Encode(rgba []byte, height uint32, width uint32, channels uint8, colorspace uint8) ([]byte, error) {
-	expectedPixelsCount := height * width
+// END_MARKER is the 8-byte sequence that terminates every QOI stream.
+var END_MARKER = []byte{0, 0, 0, 0, 0, 0, 0, 1}
 
-	if len(rgba) != int(expectedPixelsCount)*4 {
-		return nil, fmt.Errorf("insufficient rgba data for the expected height and width, h: %d w: %d r: %d required: %d", height, width, len(rgba), int(expectedPixelsCount)*int(channels))
-	}
+// Encoder streams QOI-encoded pixels directly to an io.Writer as they are
+// written, so callers never have to materialize the whole encoded output in
+// memory. Equal consecutive pixels are buffered as a pending QOI_OP_RUN
+// internally, since the run length can't be known until it ends; every
+// other op is written out immediately.
+type Encoder struct {
+	w          io.Writer
+	height     uint32
+	width      uint32
+	channels   uint8
+	colorspace uint8
+
+	historyBuffer [64]pixel
+	previousPixel pixel
+	previousType  chunkType
+
+	runPending bool
+	runPixel   pixel
+	runCount   uint8 // bias of 1
+}
 
-	buffer := []byte(MAGIC_BYTES)
-	buffer = binary.BigEndian.AppendUint32(buffer, width)
-	buffer = binary.BigEndian.AppendUint32(buffer, height)
-	buffer = append(buffer, channels, colorspace)
+// NewEncoder writes the QOI header to w and returns an Encoder ready to
+// accept height*width pixels via WritePixel, in row-major order.
+func NewEncoder(w io.Writer, height uint32, width uint32, channels uint8, colorspace uint8) (*Encoder, error) {
+	buf := []byte(MAGIC_BYTES)
+	buf = binary.BigEndian.AppendUint32(buf, width)
+	buf = binary.BigEndian.AppendUint32(buf, height)
+	buf = append(buf, channels, colorspace)
 
-	if len(buffer) != 14 {
-		panic(fmt.Sprintf("Header was encoded incorrectly, expect 14 bytes, found %d bytes. encoded header - %08b", len(buffer), buffer))
+	if len(buf) != 14 {
+		panic(fmt.Sprintf("Header was encoded incorrectly, expect 14 bytes, found %d bytes. encoded header - %08b", len(buf), buf))
 	}
 
-	s := newState()
+	if _, err := w.Write(buf); err != nil {
+		return nil, err
+	}
 
-	idx := 0
-	var pixelsWritten uint32 = 0
+	e := &Encoder{w: w, height: height, width: width, channels: channels, colorspace: colorspace}
+	e.previousPixel = pixel{R: 0, G: 0, B: 0, A: 255}
+	return e, nil
+}
 
-	for pixelsWritten < expectedPixelsCount && idx < len(rgba) {
-		currPixel := pixel{
-			R: rgba[idx],
-			G: rgba[idx+1],
-			B: rgba[idx+2],
-			A: rgba[idx+3],
-		}
+// WritePixel encodes a single pixel. Pixels must be supplied in row-major
+// order matching the height/width passed to NewEncoder.
+func (e *Encoder) WritePixel(c color.NRGBA) error {
+	currPixel := pixel{R: c.R, G: c.G, B: c.B, A: c.A}
+	return e.writePixel(currPixel, currPixel.Hash())
+}
 
-		if currPixel.Equals(s.previousPixel) {
-			var count uint8 = 0 // bias of 1
-			rIdx := idx + 4
-			for pixelsWritten < expectedPixelsCount && rIdx < len(rgba) && count < 61 {
-				runPixel := pixel{
-					R: rgba[rIdx],
-					G: rgba[rIdx+1],
-					B: rgba[rIdx+2],
-					A: rgba[rIdx+3],
-				}
-				if currPixel.Equals(runPixel) {
-					count += 1
-					rIdx += 4
-				} else {
-					break
-				}
+// WritePixels encodes a contiguous buffer of RGBA8 pixels (R,G,B,A
+// repeating, the same layout image.NRGBA.Pix uses), in row-major order. It
+// behaves exactly like calling WritePixel once per pixel, but uses
+// qoiasm.RunLength to discover how long a run of pixels equal to the
+// currently pending run extends in one pass, instead of rediscovering it one
+// equality check at a time the way WritePixel does.
+//
+// An earlier version of this method also batched history-table hashing via
+// qoiasm.HashPixels, computing hashes ahead of where it was writing. That was
+// dropped: hashing pixels that a run absorbs is wasted work in the common
+// case of flat image regions, and benchmarking (BenchmarkEncode, before and
+// after) showed no end-to-end win over hashing lazily per pixel as below —
+// the batched hash kernel is faster in isolation, but the branching and
+// io.Writer calls around it dominate this encoder's actual cost, so nothing
+// downstream of the hash ever got to see the saved time.
+func (e *Encoder) WritePixels(pix []byte) error {
+	n := len(pix) / 4
+	if n == 0 {
+		return nil
+	}
+
+	for i := 0; i < n; {
+		currPixel := pixel{R: pix[i*4], G: pix[i*4+1], B: pix[i*4+2], A: pix[i*4+3]}
+
+		if e.runPending && currPixel.Equals(e.runPixel) {
+			// e.runCount can be at most 60 here: it's flushed the moment it
+			// reaches 61 below, in this call or a prior one.
+			room := int(61 - e.runCount)
+			additional := 0
+			if room > 1 {
+				additional = qoiasm.RunLength(pix[i*4:], room-1)
 			}
-			idx = rIdx
-			s.previousType = qoi_op_run
-			s.previousPixel = currPixel
-			s.historyBuffer[currPixel.Hash()] = currPixel
-			buffer = append(buffer, count|0b11000000)
-			pixelsWritten += (uint32(count) + 1)
-			continue
-		} else {
-			if s.historyBuffer[currPixel.Hash()].Equals(currPixel) {
-				// check if previous chunk was also a QOI_OP_INDEX hashed to same index
-				if s.previousType == qoi_op_index && s.previousPixel.Hash() == currPixel.Hash() {
-					// spec disallows 2 consecutive QOI_OP_INDEX hashed to same index
-					var count uint8 = 0 // bias of 1
-					rIdx := idx + 4
-					for pixelsWritten < expectedPixelsCount && rIdx < len(rgba) && count < 61 {
-						// fmt.Printf("prev idx -> checking runlength rIdx: %d idx: %d count: %d\n", rIdx, idx, count)
-						runPixel := pixel{
-							R: rgba[rIdx],
-							G: rgba[rIdx+1],
-							B: rgba[rIdx+2],
-							A: rgba[rIdx+3],
-						}
-						if currPixel.Equals(runPixel) {
-							count += 1
-							rIdx += 4
-						} else {
-							break
-						}
-					}
-					idx = rIdx
-					s.previousType = qoi_op_run
-					s.previousPixel = currPixel
-					s.historyBuffer[currPixel.Hash()] = currPixel
-					buffer = append(buffer, count|0b11000000)
-					pixelsWritten += (uint32(count) + 1)
-					continue
-				} else {
-					// QOI_OP_INDEX
-					idx += 4
-					s.previousType = qoi_op_index
-					s.previousPixel = currPixel
-					s.historyBuffer[currPixel.Hash()] = currPixel
-					buffer = append(buffer, currPixel.Hash())
-					pixelsWritten += 1
-					continue
+			total := 1 + additional
+			e.runCount += uint8(total)
+			i += total
+			if e.runCount == 61 {
+				if err := e.flushRun(); err != nil {
+					return err
 				}
 			}
-			// check if buffer can be stored as diff using either QOI_OP_DIFF or QOI_OP_LUMA
-			if channels == 3 || currPixel.A == s.previousPixel.A {
-				// check if QOI_OP_DIFF
-				var bias uint8 = 2
-				rDiff := currPixel.R - s.previousPixel.R + bias
-				gDiff := currPixel.G - s.previousPixel.G + bias
-				bDiff := currPixel.B - s.previousPixel.B + bias
-				if rDiff < 4 && gDiff < 4 && bDiff < 4 {
-					// valid QOI_OP_DIFF
-					idx += 4
-					s.previousType = qoi_op_diff
-					s.previousPixel = currPixel
-					s.historyBuffer[currPixel.Hash()] = currPixel
-					buffer = append(buffer, 0b01000000|rDiff<<4|gDiff<<2|bDiff)
-					pixelsWritten += 1
-					continue
-				}
+			continue
+		}
 
-				// check if QOI_OP_LUMA
-				var greenBias uint8 = 32
-				var redBias uint8 = 8
-				var blueBias uint8 = 8
-
-				dg := currPixel.G - s.previousPixel.G + greenBias
-				dr_dg := (currPixel.R - s.previousPixel.R) - (currPixel.G - s.previousPixel.G) + redBias
-				db_dg := (currPixel.B - s.previousPixel.B) - (currPixel.G - s.previousPixel.G) + blueBias
-
-				if dg <= 63 && dr_dg <= 15 && db_dg <= 15 {
-					// valid QOI_OP_LUMA
-					idx += 4
-					s.previousType = qoi_op_luma
-					s.previousPixel = currPixel
-					s.historyBuffer[currPixel.Hash()] = currPixel
-					buffer = append(buffer, 0b10000000|dg)
-					buffer = append(buffer, dr_dg<<4|db_dg)
-					pixelsWritten += 1
-					continue
-				}
+		if err := e.writePixel(currPixel, currPixel.Hash()); err != nil {
+			return err
+		}
+		i++
+	}
 
-				// QOI_OP_RGB
-				idx += 4
-				s.previousType = qoi_op_rgb
-				s.previousPixel = currPixel
-				s.historyBuffer[currPixel.Hash()] = currPixel
-				buffer = append(buffer, 0b11111110)
-				buffer = append(buffer, currPixel.R)
-				buffer = append(buffer, currPixel.G)
-				buffer = append(buffer, currPixel.B)
-				pixelsWritten += 1
-				continue
-
-			} else {
-				// QOI_OP_RGBA
-				idx += 4
-				s.previousType = qoi_op_rgba
-				s.previousPixel = currPixel
-				s.historyBuffer[currPixel.Hash()] = currPixel
-				buffer = append(buffer, 0b11111111)
-				buffer = append(buffer, currPixel.R)
-				buffer = append(buffer, currPixel.G)
-				buffer = append(buffer, currPixel.B)
-				buffer = append(buffer, currPixel.A)
-				pixelsWritten += 1
-				continue
-			}
+	return nil
+}
+
+// writePixel is WritePixel's implementation, taking currPixel's
+// history-table hash as a parameter; both WritePixel and WritePixels compute
+// it with currPixel.Hash() just before the call.
+func (e *Encoder) writePixel(currPixel pixel, hash uint8) error {
+	if e.runPending && currPixel.Equals(e.runPixel) {
+		e.runCount += 1
+		if e.runCount == 61 {
+			return e.flushRun()
+		}
+		return nil
+	}
+
+	if e.runPending {
+		if err := e.flushRun(); err != nil {
+			return err
 		}
 	}
 
-	return slices.Concat(buffer, END_MARKER), nil
+	if currPixel.Equals(e.previousPixel) {
+		e.startRun(currPixel)
+		return nil
+	}
+
+	return e.writeNonRun(currPixel, hash)
+}
+
+// startRun begins buffering a pending QOI_OP_RUN for p; its length grows as
+// further equal pixels arrive and is only known once the run ends.
+func (e *Encoder) startRun(p pixel) {
+	e.runPending = true
+	e.runPixel = p
+	e.runCount = 0
+}
+
+// writeNonRun writes currPixel as anything other than a QOI_OP_RUN; hash
+// must be currPixel.Hash(), passed through from writePixel rather than
+// recomputed.
+func (e *Encoder) writeNonRun(currPixel pixel, hash uint8) error {
+	if e.historyBuffer[hash].Equals(currPixel) {
+		// currPixel != e.previousPixel here (writePixel already diverted that
+		// case into a run before calling writeNonRun), so this can't be two
+		// consecutive QOI_OP_INDEX chunks hashing to the same slot - that
+		// would require historyBuffer[hash], currPixel, and previousPixel to
+		// all be equal, which is exactly the case writePixel ruled out.
+		e.previousType = qoi_op_index
+		e.previousPixel = currPixel
+		e.historyBuffer[hash] = currPixel
+		return e.writeBytes(hash)
+	}
+
+	// check if pixel can be stored as diff using either QOI_OP_DIFF or QOI_OP_LUMA
+	if e.channels == 3 || currPixel.A == e.previousPixel.A {
+		var bias uint8 = 2
+		rDiff := currPixel.R - e.previousPixel.R + bias
+		gDiff := currPixel.G - e.previousPixel.G + bias
+		bDiff := currPixel.B - e.previousPixel.B + bias
+		if rDiff < 4 && gDiff < 4 && bDiff < 4 {
+			// valid QOI_OP_DIFF
+			e.previousType = qoi_op_diff
+			e.previousPixel = currPixel
+			e.historyBuffer[hash] = currPixel
+			return e.writeBytes(0b01000000 | rDiff<<4 | gDiff<<2 | bDiff)
+		}
+
+		// check if QOI_OP_LUMA
+		var greenBias uint8 = 32
+		var redBias uint8 = 8
+		var blueBias uint8 = 8
+
+		dg := currPixel.G - e.previousPixel.G + greenBias
+		dr_dg := (currPixel.R - e.previousPixel.R) - (currPixel.G - e.previousPixel.G) + redBias
+		db_dg := (currPixel.B - e.previousPixel.B) - (currPixel.G - e.previousPixel.G) + blueBias
+
+		if dg <= 63 && dr_dg <= 15 && db_dg <= 15 {
+			// valid QOI_OP_LUMA
+			e.previousType = qoi_op_luma
+			e.previousPixel = currPixel
+			e.historyBuffer[hash] = currPixel
+			return e.writeBytes(0b10000000|dg, dr_dg<<4|db_dg)
+		}
+
+		// QOI_OP_RGB
+		e.previousType = qoi_op_rgb
+		e.previousPixel = currPixel
+		e.historyBuffer[hash] = currPixel
+		return e.writeBytes(0b11111110, currPixel.R, currPixel.G, currPixel.B)
+	}
+
+	// QOI_OP_RGBA
+	e.previousType = qoi_op_rgba
+	e.previousPixel = currPixel
+	e.historyBuffer[hash] = currPixel
+	return e.writeBytes(0b11111111, currPixel.R, currPixel.G, currPixel.B, currPixel.A)
+}
+
+func (e *Encoder) flushRun() error {
+	e.previousType = qoi_op_run
+	e.previousPixel = e.runPixel
+	e.historyBuffer[e.runPixel.Hash()] = e.runPixel
+
+	count := e.runCount
+	e.runPending = false
+	e.runCount = 0
+
+	return e.writeBytes(count | 0b11000000)
+}
+
+func (e *Encoder) writeBytes(bs ...byte) error {
+	_, err := e.w.Write(bs)
+	return err
+}
+
+// Close flushes any pending run and writes the QOI end marker. It must be
+// called after the last pixel has been written to the encoder.
+func (e *Encoder) Close() error {
+	if e.runPending {
+		if err := e.flushRun(); err != nil {
+			return err
+		}
+	}
+	_, err := e.w.Write(END_MARKER)
+	return err
+}
+
+// Encode encodes a complete in-memory RGBA buffer as a QOI file. It is a
+// thin wrapper around Encoder for callers that already have the whole raster
+// buffered.
+func Encode(rgba []byte, height uint32, width uint32, channels uint8, colorspace uint8) ([]byte, error) {
+	expectedPixelsCount := height * width
+
+	if len(rgba) != int(expectedPixelsCount)*4 {
+		return nil, fmt.Errorf("insufficient rgba data for the expected height and width, h: %d w: %d r: %d required: %d", height, width, len(rgba), int(expectedPixelsCount)*int(channels))
+	}
+
+	var buffer bytes.Buffer
+	e, err := NewEncoder(&buffer, height, width, channels, colorspace)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := e.WritePixels(rgba); err != nil {
+		return nil, err
+	}
+
+	if err := e.Close(); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
 }
 
 func imageToNRGBA(src image.Image) *image.NRGBA {
@@ -179,31 +273,197 @@ func imageToNRGBA(src image.Image) *image.NRGBA {
 	return dst
 }
 
+// ImageEncode writes m to w as a QOI file using the default options: see
+// EncodeWithOptions.
 func ImageEncode(w io.Writer, m image.Image) error {
+	return EncodeWithOptions(w, m, nil)
+}
+
+// EncoderOptions configures EncodeWithOptions, mirroring image/png's
+// Encoder options struct.
+type EncoderOptions struct {
+	// Channels forces the channel count written to the header (3 or 4).
+	// Zero means a default chosen by the source image's concrete type: 4
+	// for *image.NRGBA, *image.RGBA and *image.Paletted (and with
+	// ForceRGB), 3 for *image.Gray, *image.YCbCr and every other type,
+	// whether or not that type can carry alpha.
+	Channels uint8
+	// Colorspace is written verbatim into the header's colorspace byte (0
+	// for sRGB, 1 for "linear", per the QOI spec).
+	Colorspace uint8
+	// ForceRGB routes the image through imageToNRGBA + draw.Draw instead of
+	// using a type-specific fast path below, whatever its concrete type.
+	ForceRGB bool
+}
+
+// EncodeWithOptions writes m to w as a QOI file. It reads pixels directly
+// from *image.NRGBA, *image.RGBA (un-premultiplying), *image.Gray,
+// *image.YCbCr, and *image.Paletted without an intermediate NRGBA
+// conversion; any other image type is consumed row-by-row via image.Image.At
+// instead, unless opts.ForceRGB routes it through imageToNRGBA + draw.Draw.
+// A nil opts behaves like &EncoderOptions{}.
+func EncodeWithOptions(w io.Writer, m image.Image, opts *EncoderOptions) error {
+	if opts == nil {
+		opts = &EncoderOptions{}
+	}
+
+	if opts.ForceRGB {
+		return encodeNRGBA(w, imageToNRGBA(m), channelsOrDefault(opts.Channels, 4), opts.Colorspace)
+	}
+
 	switch src := m.(type) {
 	case *image.NRGBA:
-		{
-			data, err := Encode(src.Pix, uint32(src.Bounds().Max.Y), uint32(src.Bounds().Max.X), 4, 0)
-			if err != nil {
+		return encodeNRGBA(w, src, channelsOrDefault(opts.Channels, 4), opts.Colorspace)
+	case *image.RGBA:
+		return encodeRGBA(w, src, channelsOrDefault(opts.Channels, 4), opts.Colorspace)
+	case *image.Gray:
+		return encodeGray(w, src, channelsOrDefault(opts.Channels, 3), opts.Colorspace)
+	case *image.YCbCr:
+		return encodeYCbCr(w, src, channelsOrDefault(opts.Channels, 3), opts.Colorspace)
+	case *image.Paletted:
+		return encodePaletted(w, src, channelsOrDefault(opts.Channels, 4), opts.Colorspace)
+	default:
+		return encodeGeneric(w, src, channelsOrDefault(opts.Channels, 3), opts.Colorspace)
+	}
+}
+
+// encodeGeneric handles any image.Image whose concrete type has no
+// dedicated fast path above, reading pixels one at a time via At instead of
+// converting the whole image to *image.NRGBA first.
+func encodeGeneric(w io.Writer, src image.Image, channels, colorspace uint8) error {
+	b := src.Bounds()
+	e, err := NewEncoder(w, uint32(b.Dy()), uint32(b.Dx()), channels, colorspace)
+	if err != nil {
+		return err
+	}
+
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(src.At(x, y)).(color.NRGBA)
+			if err := e.WritePixel(c); err != nil {
+				return err
+			}
+		}
+	}
+
+	return e.Close()
+}
+
+func channelsOrDefault(channels, def uint8) uint8 {
+	if channels == 0 {
+		return def
+	}
+	return channels
+}
+
+func encodeNRGBA(w io.Writer, src *image.NRGBA, channels, colorspace uint8) error {
+	b := src.Bounds()
+	e, err := NewEncoder(w, uint32(b.Dy()), uint32(b.Dx()), channels, colorspace)
+	if err != nil {
+		return err
+	}
+
+	width := b.Dx()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		rowStart := src.PixOffset(b.Min.X, y)
+		row := src.Pix[rowStart : rowStart+width*4]
+		if err := e.WritePixels(row); err != nil {
+			return err
+		}
+	}
+
+	return e.Close()
+}
+
+func encodeRGBA(w io.Writer, src *image.RGBA, channels, colorspace uint8) error {
+	b := src.Bounds()
+	e, err := NewEncoder(w, uint32(b.Dy()), uint32(b.Dx()), channels, colorspace)
+	if err != nil {
+		return err
+	}
+
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			i := src.PixOffset(x, y)
+			r, g, bl, a := src.Pix[i], src.Pix[i+1], src.Pix[i+2], src.Pix[i+3]
+			if a != 0 && a != 255 {
+				r = uint8(uint32(r) * 255 / uint32(a))
+				g = uint8(uint32(g) * 255 / uint32(a))
+				bl = uint8(uint32(bl) * 255 / uint32(a))
+			}
+			if err := e.WritePixel(color.NRGBA{R: r, G: g, B: bl, A: a}); err != nil {
 				return err
 			}
-			_, err = w.Write(data)
-			if err != nil {
+		}
+	}
+
+	return e.Close()
+}
+
+func encodeGray(w io.Writer, src *image.Gray, channels, colorspace uint8) error {
+	b := src.Bounds()
+	e, err := NewEncoder(w, uint32(b.Dy()), uint32(b.Dx()), channels, colorspace)
+	if err != nil {
+		return err
+	}
+
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			v := src.Pix[src.PixOffset(x, y)]
+			if err := e.WritePixel(color.NRGBA{R: v, G: v, B: v, A: 255}); err != nil {
 				return err
 			}
 		}
-	default:
-		{
-			nrgbaImage := imageToNRGBA(src)
-			data, err := Encode(nrgbaImage.Pix, uint32(nrgbaImage.Bounds().Max.Y), uint32(nrgbaImage.Bounds().Max.X), 3, 0)
-			if err != nil {
+	}
+
+	return e.Close()
+}
+
+func encodeYCbCr(w io.Writer, src *image.YCbCr, channels, colorspace uint8) error {
+	b := src.Bounds()
+	e, err := NewEncoder(w, uint32(b.Dy()), uint32(b.Dx()), channels, colorspace)
+	if err != nil {
+		return err
+	}
+
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			yi := src.YOffset(x, y)
+			ci := src.COffset(x, y)
+			r, g, bl := color.YCbCrToRGB(src.Y[yi], src.Cb[ci], src.Cr[ci])
+			if err := e.WritePixel(color.NRGBA{R: r, G: g, B: bl, A: 255}); err != nil {
 				return err
 			}
-			_, err = w.Write(data)
-			if err != nil {
+		}
+	}
+
+	return e.Close()
+}
+
+// encodePaletted encodes a *image.Paletted image, using the precomputed
+// index fast path (see palette.go) whenever the palette qualifies for it.
+func encodePaletted(w io.Writer, src *image.Paletted, channels, colorspace uint8) error {
+	if ok, err := encodePaletteFast(w, src, channels, colorspace); ok {
+		return err
+	}
+	return encodePalettedGeneric(w, src, channels, colorspace)
+}
+
+func encodePalettedGeneric(w io.Writer, src *image.Paletted, channels, colorspace uint8) error {
+	b := src.Bounds()
+	e, err := NewEncoder(w, uint32(b.Dy()), uint32(b.Dx()), channels, colorspace)
+	if err != nil {
+		return err
+	}
+
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(src.Palette[src.Pix[src.PixOffset(x, y)]]).(color.NRGBA)
+			if err := e.WritePixel(c); err != nil {
 				return err
 			}
 		}
 	}
-	return nil
+
+	return e.Close()
 }
@@ -3,6 +3,8 @@ package qoi_test
 import (
 	"bytes"
 	"image"
+	"image/color"
+	"math/rand"
 	"os"
 	"path/filepath"
 	"strings"
@@ -50,6 +52,162 @@ func TestImageEncoding(t *testing.T) {
 				t.Logf("got=%08b\nwant=%08b\n", buffer.Bytes()[0: idx+1], qoiFile[0: idx+1])
 				t.Fatalf("failed to encode qoi file from pngFile %v correctly got=%08b want=%08b at index %v", pngFile, currByte , qoiFile[idx], idx)
 			}
-		}		
+		}
+	}
+}
+
+// smallPalette is 8 colors whose QOI history-table hashes (see pixel.Hash)
+// don't collide, so images built from it qualify for encodePaletteFast.
+var smallPalette = color.Palette{
+	color.NRGBA{R: 0, G: 0, B: 0, A: 255},
+	color.NRGBA{R: 255, G: 0, B: 0, A: 255},
+	color.NRGBA{R: 0, G: 255, B: 0, A: 255},
+	color.NRGBA{R: 0, G: 0, B: 255, A: 255},
+	color.NRGBA{R: 255, G: 255, B: 0, A: 255},
+	color.NRGBA{R: 0, G: 255, B: 255, A: 255},
+	color.NRGBA{R: 255, G: 0, B: 255, A: 255},
+	color.NRGBA{R: 255, G: 255, B: 255, A: 255},
+}
+
+// palettedImage builds a w*h *image.Paletted out of smallPalette, cycling
+// through it the same way paletteRGBA does.
+func palettedImage(w, h int) *image.Paletted {
+	img := image.NewPaletted(image.Rect(0, 0, w, h), smallPalette)
+	for i := 0; i < w*h; i++ {
+		img.Pix[i] = uint8((i * 7) % len(smallPalette))
+	}
+	return img
+}
+
+// TestEncodePaletteFastMatchesGeneric proves encodePaletteFast round-trips
+// to the same image as the general-purpose encoder, by comparing the
+// default EncodeWithOptions path (which takes the fast path for a
+// qualifying *image.Paletted) against ForceRGB, which always routes through
+// the generic per-pixel encoder instead. The two aren't expected to produce
+// byte-identical output - encodePaletteFast deliberately skips QOI_OP_DIFF/
+// QOI_OP_LUMA on a color's first occurrence (see writeFullPixel) - only the
+// same decoded pixels.
+func TestEncodePaletteFastMatchesGeneric(t *testing.T) {
+	img := palettedImage(37, 29)
+
+	var fast bytes.Buffer
+	if err := qoi.EncodeWithOptions(&fast, img, nil); err != nil {
+		t.Fatalf("fast-path encode failed: %v", err)
+	}
+
+	var generic bytes.Buffer
+	if err := qoi.EncodeWithOptions(&generic, img, &qoi.EncoderOptions{ForceRGB: true}); err != nil {
+		t.Fatalf("generic encode failed: %v", err)
 	}
+
+	fastImg, err := qoi.ImageDecode(&fast)
+	if err != nil {
+		t.Fatalf("failed to decode fast-path output: %v", err)
+	}
+	genericImg, err := qoi.ImageDecode(&generic)
+	if err != nil {
+		t.Fatalf("failed to decode generic output: %v", err)
+	}
+
+	b := img.Bounds()
+	if !fastImg.Bounds().Eq(b) || !genericImg.Bounds().Eq(b) {
+		t.Fatalf("bounds mismatch: fast=%v generic=%v want=%v", fastImg.Bounds(), genericImg.Bounds(), b)
+	}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			fr, fg, fb, fa := fastImg.At(x, y).RGBA()
+			gr, gg, gb, ga := genericImg.At(x, y).RGBA()
+			if fr != gr || fg != gg || fb != gb || fa != ga {
+				t.Fatalf("pixel (%d,%d) mismatch: fast=%v generic=%v", x, y, fastImg.At(x, y), genericImg.At(x, y))
+			}
+		}
+	}
+}
+
+const benchWidth, benchHeight = 256, 256
+
+// solidRGBA is one color repeated for the whole image, the best case for
+// QOI_OP_RUN.
+func solidRGBA(w, h int) []byte {
+	buf := make([]byte, w*h*4)
+	for i := 0; i < len(buf); i += 4 {
+		buf[i], buf[i+1], buf[i+2], buf[i+3] = 10, 20, 30, 255
+	}
+	return buf
+}
+
+// paletteRGBA cycles through a small, non-adjacent palette, the case
+// encodePaletteFast targets.
+func paletteRGBA(w, h int) []byte {
+	palette := [][4]byte{
+		{0, 0, 0, 255}, {255, 0, 0, 255}, {0, 255, 0, 255}, {0, 0, 255, 255},
+		{255, 255, 0, 255}, {0, 255, 255, 255}, {255, 0, 255, 255}, {255, 255, 255, 255},
+	}
+	buf := make([]byte, w*h*4)
+	for i := 0; i < w*h; i++ {
+		c := palette[(i*7)%len(palette)]
+		copy(buf[i*4:i*4+4], c[:])
+	}
+	return buf
+}
+
+// photographicRGBA is opaque noise, approximating a photo where few pixels
+// repeat or sit near their neighbor.
+func photographicRGBA(w, h int) []byte {
+	buf := make([]byte, w*h*4)
+	rand.New(rand.NewSource(1)).Read(buf)
+	for i := 3; i < len(buf); i += 4 {
+		buf[i] = 255
+	}
+	return buf
+}
+
+func BenchmarkEncode(b *testing.B) {
+	cases := []struct {
+		name string
+		rgba []byte
+	}{
+		{"Solid", solidRGBA(benchWidth, benchHeight)},
+		{"Palette", paletteRGBA(benchWidth, benchHeight)},
+		{"Photographic", photographicRGBA(benchWidth, benchHeight)},
+	}
+
+	for _, c := range cases {
+		b.Run(c.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := qoi.Encode(c.rgba, benchHeight, benchWidth, 4, 0); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkEncodePaletted exercises encodePaletteFast specifically, the
+// optimization an *image.Paletted source is supposed to unlock; it's not
+// reachable through BenchmarkEncode, which only ever feeds raw bytes through
+// qoi.Encode.
+func BenchmarkEncodePaletted(b *testing.B) {
+	img := palettedImage(benchWidth, benchHeight)
+
+	b.Run("Fast", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var buf bytes.Buffer
+			if err := qoi.EncodeWithOptions(&buf, img, nil); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("Generic", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var buf bytes.Buffer
+			if err := qoi.EncodeWithOptions(&buf, img, &qoi.EncoderOptions{ForceRGB: true}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
 }
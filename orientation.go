@@ -0,0 +1,142 @@
+package qoi
+
+import (
+	"image"
+	"image/color"
+	"io"
+)
+
+// ImageEncodeWithOrientation applies the EXIF orientation transform (values
+// 1-8, per the EXIF spec) to m before encoding it as QOI, so pipelines that
+// pull frames out of JPEG/HEIC sources retain their visually correct
+// orientation in the resulting QOI file.
+func ImageEncodeWithOrientation(w io.Writer, m image.Image, orientation int) error {
+	return ImageEncode(w, ApplyOrientation(m, orientation))
+}
+
+// ApplyOrientation returns img rotated/flipped according to the given EXIF
+// orientation value. Orientation 1, or any value outside 1-8, returns img
+// unchanged.
+func ApplyOrientation(img image.Image, orientation int) image.Image {
+	switch orientation {
+	case 2:
+		return flipH(img)
+	case 3:
+		return rotate180(img)
+	case 4:
+		return flipV(img)
+	case 5:
+		return transpose(img)
+	case 6:
+		return rotate90CW(img)
+	case 7:
+		return transverse(img)
+	case 8:
+		return rotate270CW(img)
+	default:
+		return img
+	}
+}
+
+func ensureNRGBA(img image.Image) *image.NRGBA {
+	if src, ok := img.(*image.NRGBA); ok {
+		return src
+	}
+	return imageToNRGBA(img)
+}
+
+func nrgbaAt(src *image.NRGBA, b image.Rectangle, x, y int) color.NRGBA {
+	i := src.PixOffset(b.Min.X+x, b.Min.Y+y)
+	return color.NRGBA{R: src.Pix[i], G: src.Pix[i+1], B: src.Pix[i+2], A: src.Pix[i+3]}
+}
+
+func flipH(img image.Image) image.Image {
+	src := ensureNRGBA(img)
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dst.SetNRGBA(x, y, nrgbaAt(src, b, w-1-x, y))
+		}
+	}
+	return dst
+}
+
+func flipV(img image.Image) image.Image {
+	src := ensureNRGBA(img)
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dst.SetNRGBA(x, y, nrgbaAt(src, b, x, h-1-y))
+		}
+	}
+	return dst
+}
+
+func rotate180(img image.Image) image.Image {
+	src := ensureNRGBA(img)
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dst.SetNRGBA(x, y, nrgbaAt(src, b, w-1-x, h-1-y))
+		}
+	}
+	return dst
+}
+
+func transpose(img image.Image) image.Image {
+	src := ensureNRGBA(img)
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dst := image.NewNRGBA(image.Rect(0, 0, h, w))
+	for y := 0; y < w; y++ {
+		for x := 0; x < h; x++ {
+			dst.SetNRGBA(x, y, nrgbaAt(src, b, y, x))
+		}
+	}
+	return dst
+}
+
+func rotate90CW(img image.Image) image.Image {
+	src := ensureNRGBA(img)
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dst := image.NewNRGBA(image.Rect(0, 0, h, w))
+	for y := 0; y < w; y++ {
+		for x := 0; x < h; x++ {
+			dst.SetNRGBA(x, y, nrgbaAt(src, b, y, h-1-x))
+		}
+	}
+	return dst
+}
+
+func rotate270CW(img image.Image) image.Image {
+	src := ensureNRGBA(img)
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dst := image.NewNRGBA(image.Rect(0, 0, h, w))
+	for y := 0; y < w; y++ {
+		for x := 0; x < h; x++ {
+			dst.SetNRGBA(x, y, nrgbaAt(src, b, w-1-y, x))
+		}
+	}
+	return dst
+}
+
+func transverse(img image.Image) image.Image {
+	src := ensureNRGBA(img)
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dst := image.NewNRGBA(image.Rect(0, 0, h, w))
+	for y := 0; y < w; y++ {
+		for x := 0; x < h; x++ {
+			dst.SetNRGBA(x, y, nrgbaAt(src, b, w-1-y, h-1-x))
+		}
+	}
+	return dst
+}
@@ -0,0 +1,109 @@
+package qoi
+
+import (
+	"image"
+	"image/color"
+	"io"
+)
+
+// encodePaletteFast handles a *image.Paletted image whose palette has at
+// most 64 colors with no QOI hash collisions among them. With that
+// guarantee, every palette entry owns a fixed, unique history-table slot
+// for the whole image, so the first occurrence of a color is written out in
+// full and every later occurrence becomes a QOI_OP_INDEX straight away,
+// without recomputing a hash or retrying QOI_OP_DIFF/QOI_OP_LUMA for pixels
+// we already know are repeats. It reports ok=false when the palette doesn't
+// qualify, in which case the caller should fall back to the general-purpose
+// encoder.
+func encodePaletteFast(w io.Writer, src *image.Paletted, channels, colorspace uint8) (ok bool, err error) {
+	if len(src.Palette) > 64 {
+		return false, nil
+	}
+
+	entries := make([]pixel, len(src.Palette))
+	hashes := make([]uint8, len(src.Palette))
+
+	var owner [64]int
+	for i := range owner {
+		owner[i] = -1
+	}
+
+	for i, c := range src.Palette {
+		nrgba := color.NRGBAModel.Convert(c).(color.NRGBA)
+		p := pixel{R: nrgba.R, G: nrgba.G, B: nrgba.B, A: nrgba.A}
+		h := p.Hash()
+		if o := owner[h]; o != -1 && !entries[o].Equals(p) {
+			// two distinct palette colors hash to the same slot; the fast
+			// path can't guarantee correct INDEX lookups, so bail out.
+			return false, nil
+		}
+		owner[h] = i
+		entries[i] = p
+		hashes[i] = h
+	}
+
+	b := src.Bounds()
+	e, err := NewEncoder(w, uint32(b.Dy()), uint32(b.Dx()), channels, colorspace)
+	if err != nil {
+		return true, err
+	}
+
+	seen := make([]bool, len(src.Palette))
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			idx := src.Pix[src.PixOffset(x, y)]
+			p := entries[idx]
+
+			if e.runPending && p.Equals(e.runPixel) {
+				e.runCount += 1
+				if e.runCount == 61 {
+					if err := e.flushRun(); err != nil {
+						return true, err
+					}
+				}
+				continue
+			}
+			if e.runPending {
+				if err := e.flushRun(); err != nil {
+					return true, err
+				}
+			}
+			if p.Equals(e.previousPixel) {
+				e.startRun(p)
+				continue
+			}
+
+			if seen[idx] {
+				e.previousType = qoi_op_index
+				e.previousPixel = p
+				if err := e.writeBytes(hashes[idx]); err != nil {
+					return true, err
+				}
+				continue
+			}
+
+			seen[idx] = true
+			if err := e.writeFullPixel(p); err != nil {
+				return true, err
+			}
+		}
+	}
+
+	return true, e.Close()
+}
+
+// writeFullPixel writes p as a QOI_OP_RGB or QOI_OP_RGBA chunk, whichever
+// the alpha channel requires, without attempting QOI_OP_DIFF/QOI_OP_LUMA.
+// It's used by encodePaletteFast, where every call site already knows p is
+// a color the encoder hasn't emitted before.
+func (e *Encoder) writeFullPixel(p pixel) error {
+	if e.channels == 3 || p.A == e.previousPixel.A {
+		e.previousType = qoi_op_rgb
+		e.previousPixel = p
+		return e.writeBytes(0b11111110, p.R, p.G, p.B)
+	}
+
+	e.previousType = qoi_op_rgba
+	e.previousPixel = p
+	return e.writeBytes(0b11111111, p.R, p.G, p.B, p.A)
+}
@@ -0,0 +1,59 @@
+//go:build amd64 || arm64
+
+package qoiasm
+
+// hashLanesMask keeps only the low 6 bits of each of the four 16-bit lanes.
+const hashLanesMask = 0x003F003F003F003F
+
+// hashPixels computes 4 pixels' history-table hashes per iteration by
+// spreading each channel's bytes into the four 16-bit lanes of a uint64,
+// one lane per pixel. Since every lane's running sum tops out at
+// 255*3+255*5+255*7+255*11 = 6630, far under a lane's 65536 range, a
+// single 64-bit multiply-add updates all 4 pixels at once without one
+// lane's arithmetic carrying into its neighbor.
+func hashPixels(buf []byte, out []uint8) {
+	n := len(buf) / 4
+	i := 0
+	for ; i+4 <= n; i += 4 {
+		chunk := buf[i*4 : i*4+16 : i*4+16]
+		r := uint64(chunk[0]) | uint64(chunk[4])<<16 | uint64(chunk[8])<<32 | uint64(chunk[12])<<48
+		g := uint64(chunk[1]) | uint64(chunk[5])<<16 | uint64(chunk[9])<<32 | uint64(chunk[13])<<48
+		b := uint64(chunk[2]) | uint64(chunk[6])<<16 | uint64(chunk[10])<<32 | uint64(chunk[14])<<48
+		a := uint64(chunk[3]) | uint64(chunk[7])<<16 | uint64(chunk[11])<<32 | uint64(chunk[15])<<48
+
+		sum := (r*3 + g*5 + b*7 + a*11) & hashLanesMask
+
+		dst := out[i : i+4 : i+4]
+		dst[0] = uint8(sum)
+		dst[1] = uint8(sum >> 16)
+		dst[2] = uint8(sum >> 32)
+		dst[3] = uint8(sum >> 48)
+	}
+	for ; i < n; i++ {
+		r, g, b, a := buf[i*4], buf[i*4+1], buf[i*4+2], buf[i*4+3]
+		out[i] = (r*3 + g*5 + b*7 + a*11) & 63
+	}
+}
+
+// runLength compares 4 bytes at a time (one RGBA8 pixel) against the first
+// pixel in buf as a single uint32, rather than comparing each channel
+// separately.
+func runLength(buf []byte, max int) int {
+	if len(buf) < 4 {
+		return 0
+	}
+
+	first := pack32(buf)
+	count := 0
+	for i := 4; i+4 <= len(buf) && count < max; i += 4 {
+		if pack32(buf[i:]) != first {
+			break
+		}
+		count++
+	}
+	return count
+}
+
+func pack32(buf []byte) uint32 {
+	return uint32(buf[0]) | uint32(buf[1])<<8 | uint32(buf[2])<<16 | uint32(buf[3])<<24
+}
@@ -0,0 +1,30 @@
+//go:build !amd64 && !arm64
+
+package qoiasm
+
+// hashPixels is the portable scalar fallback for architectures where the
+// 64-bit lane-packing trick in accel_64bit.go isn't known to pay off.
+func hashPixels(buf []byte, out []uint8) {
+	n := len(buf) / 4
+	for i := 0; i < n; i++ {
+		r, g, b, a := buf[i*4], buf[i*4+1], buf[i*4+2], buf[i*4+3]
+		out[i] = (r*3 + g*5 + b*7 + a*11) & 63
+	}
+}
+
+// runLength is the portable scalar fallback for run-length scanning.
+func runLength(buf []byte, max int) int {
+	if len(buf) < 4 {
+		return 0
+	}
+
+	r, g, b, a := buf[0], buf[1], buf[2], buf[3]
+	count := 0
+	for i := 4; i+4 <= len(buf) && count < max; i += 4 {
+		if buf[i] != r || buf[i+1] != g || buf[i+2] != b || buf[i+3] != a {
+			break
+		}
+		count++
+	}
+	return count
+}
@@ -0,0 +1,38 @@
+// Package qoiasm provides architecture-tuned implementations of QOI's two
+// hottest per-pixel operations: the 64-entry history-table hash and the
+// "how many more pixels match this one" run-length scan encode relies on.
+// Only RunLength is actually wired into the qoi package's encoder; see
+// HashPixels' doc comment for why batching the hash didn't carry over into
+// an end-to-end win.
+//
+// The accelerated paths (see accel_64bit.go) process 4 pixels per 64-bit
+// word using SWAR (SIMD-within-a-register) bit tricks rather than hand
+// assembly: each channel's multiply-add is packed into independent 16-bit
+// lanes of a uint64, so a single multiply/add instruction updates all 4
+// pixels at once without a lane's result overflowing into its neighbor.
+// Architectures where that packing isn't a net win fall back to the
+// portable scalar loop in fallback.go.
+package qoiasm
+
+// HashPixels computes the QOI history-table hash, (r*3+g*5+b*7+a*11)&63,
+// for each RGBA8 pixel in buf (len(buf)/4 pixels, R,G,B,A repeating) and
+// writes one byte per pixel into out, which must be at least len(buf)/4
+// long.
+//
+// HashPixels is faster than hashing one pixel at a time (see
+// BenchmarkHashPixels), but Encoder.WritePixels in the qoi package doesn't
+// call it: hashing pixels a run is about to absorb is wasted work for flat
+// regions, and end-to-end benchmarking showed the branching and io.Writer
+// calls around the hash dominate encode cost regardless, so batching it
+// never produced a measurable encode speedup. It's exported and tested on
+// its own merits as a correct, reusable kernel, not as a claim that it's
+// wired into the encoder.
+func HashPixels(buf []byte, out []uint8) {
+	hashPixels(buf, out)
+}
+
+// RunLength returns the number of pixels in buf (RGBA8, R,G,B,A repeating)
+// after the first that are equal to it, capped at max.
+func RunLength(buf []byte, max int) int {
+	return runLength(buf, max)
+}
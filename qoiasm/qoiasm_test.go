@@ -0,0 +1,63 @@
+package qoiasm_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/kriticalflare/qoi/qoiasm"
+)
+
+func scalarHash(buf []byte, out []uint8) {
+	for i := 0; i*4 < len(buf); i++ {
+		r, g, b, a := buf[i*4], buf[i*4+1], buf[i*4+2], buf[i*4+3]
+		out[i] = (r*3 + g*5 + b*7 + a*11) & 63
+	}
+}
+
+func TestHashPixelsMatchesScalar(t *testing.T) {
+	buf := make([]byte, 4*257) // not a multiple of 4 pixels, exercises the tail
+	rand.New(rand.NewSource(1)).Read(buf)
+
+	want := make([]uint8, len(buf)/4)
+	scalarHash(buf, want)
+
+	got := make([]uint8, len(buf)/4)
+	qoiasm.HashPixels(buf, got)
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("pixel %d: got hash %d, want %d", i, got[i], want[i])
+		}
+	}
+}
+
+func TestRunLength(t *testing.T) {
+	buf := []byte{1, 2, 3, 4, 1, 2, 3, 4, 1, 2, 3, 4, 9, 9, 9, 9}
+	if got := qoiasm.RunLength(buf, 61); got != 2 {
+		t.Fatalf("got %d, want 2", got)
+	}
+	if got := qoiasm.RunLength(buf, 1); got != 1 {
+		t.Fatalf("capped run: got %d, want 1", got)
+	}
+}
+
+// BenchmarkHashPixels measures the hashing kernel in isolation, not
+// end-to-end encode throughput - it's not reachable from the real encoder
+// (see HashPixels' doc comment). For the kernel that is wired in, compare
+// BenchmarkEncode/Solid's before/after numbers in encode_test.go instead.
+func BenchmarkHashPixels(b *testing.B) {
+	buf := make([]byte, 4*4096)
+	rand.New(rand.NewSource(1)).Read(buf)
+	out := make([]uint8, len(buf)/4)
+
+	b.Run("Accelerated", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			qoiasm.HashPixels(buf, out)
+		}
+	})
+	b.Run("Scalar", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			scalarHash(buf, out)
+		}
+	})
+}
@@ -0,0 +1,21 @@
+// Package register registers the qoia animated-QOI container with the
+// standard library's image package as a side effect of being imported, the
+// same way importing "image/png" does for PNG:
+//
+//	import _ "github.com/kriticalflare/qoi/register"
+//
+// Plain qoi files register themselves; importing "github.com/kriticalflare/qoi"
+// on its own is enough for image.Decode to handle them. qoia stays opt-in
+// here because, unlike qoi, decoding one means decoding and discarding every
+// frame after the first just to answer a plain image.Decode call.
+package register
+
+import (
+	"image"
+
+	"github.com/kriticalflare/qoi"
+)
+
+func init() {
+	image.RegisterFormat("qoia", qoi.ANIMATION_MAGIC_BYTES, qoi.DecodeAnimationFirstFrame, qoi.AnimationConfig)
+}